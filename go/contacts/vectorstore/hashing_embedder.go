@@ -0,0 +1,30 @@
+package vectorstore
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// HashingEmbedder is a deterministic, dependency-free EmbeddingProvider
+// meant for tests: it buckets each whitespace-separated token of the
+// input into one of Dims buckets via FNV hashing. It has no semantic
+// understanding of the text, but it's stable across runs and processes,
+// which is what test assertions need; production should inject a real
+// embedding model instead.
+type HashingEmbedder struct {
+	Dims int
+}
+
+func NewHashingEmbedder(dims int) *HashingEmbedder {
+	return &HashingEmbedder{Dims: dims}
+}
+
+func (h *HashingEmbedder) Embed(text string) ([]float32, error) {
+	vec := make([]float32, h.Dims)
+	for _, token := range strings.Fields(strings.ToLower(text)) {
+		hasher := fnv.New32a()
+		_, _ = hasher.Write([]byte(token))
+		vec[int(hasher.Sum32()%uint32(h.Dims))]++
+	}
+	return vec, nil
+}
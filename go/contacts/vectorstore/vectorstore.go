@@ -0,0 +1,164 @@
+// Package vectorstore provides a small, pluggable embedding-similarity
+// index for semantic search over contacts and users. It's intentionally
+// storage-agnostic: the default LocalStore keeps everything in memory and
+// mirrors it to a single file on disk, but EmbeddingProvider is an
+// interface so production can plug in a remote model and tests can
+// inject a deterministic one.
+package vectorstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+// EmbeddingProvider turns text into a fixed-size embedding vector.
+type EmbeddingProvider interface {
+	Embed(text string) ([]float32, error)
+}
+
+// Store is the interface semantic search consults to index and look up
+// embeddings.
+type Store interface {
+	Set(key string, vec []float32, tags []string) error
+	Get(key string) (vec []float32, tags []string, found bool)
+	Delete(key string) error
+	FindSimilar(query []float32, topK int, tagFilter string) ([]Match, error)
+}
+
+// Match is one hit from FindSimilar, ranked by cosine similarity.
+type Match struct {
+	Key        string
+	Similarity float64
+}
+
+type storedEntry struct {
+	Vec  []float32 `json:"vec"`
+	Tags []string  `json:"tags"`
+}
+
+// LocalStore is the default Store: an in-memory index backed by a single
+// JSON file on disk, so embeddings survive a restart without needing to
+// be recomputed.
+type LocalStore struct {
+	sync.RWMutex
+	path    string
+	entries map[string]storedEntry
+}
+
+// NewLocalStore opens the on-disk index at path. A missing file is treated
+// as an empty store; the file is not created until a Set or Delete writes
+// it.
+func NewLocalStore(path string) (*LocalStore, error) {
+	s := &LocalStore{
+		path:    path,
+		entries: make(map[string]storedEntry),
+	}
+	if err := s.load(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *LocalStore) load() error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(&s.entries)
+}
+
+func (s *LocalStore) flush() error {
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(s.entries); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *LocalStore) Set(key string, vec []float32, tags []string) error {
+	s.Lock()
+	defer s.Unlock()
+	s.entries[key] = storedEntry{Vec: vec, Tags: tags}
+	return s.flush()
+}
+
+func (s *LocalStore) Get(key string) ([]float32, []string, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	e, found := s.entries[key]
+	if !found {
+		return nil, nil, false
+	}
+	return e.Vec, e.Tags, true
+}
+
+func (s *LocalStore) Delete(key string) error {
+	s.Lock()
+	defer s.Unlock()
+	if _, found := s.entries[key]; !found {
+		return nil
+	}
+	delete(s.entries, key)
+	return s.flush()
+}
+
+func (s *LocalStore) FindSimilar(query []float32, topK int, tagFilter string) ([]Match, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	matches := make([]Match, 0, len(s.entries))
+	for key, e := range s.entries {
+		if tagFilter != "" && !hasTag(e.Tags, tagFilter) {
+			continue
+		}
+		sim, err := cosineSimilarity(query, e.Vec)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, Match{Key: key, Similarity: sim})
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Similarity > matches[j].Similarity })
+	if topK > 0 && len(matches) > topK {
+		matches = matches[:topK]
+	}
+	return matches, nil
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func cosineSimilarity(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vectorstore: dimension mismatch (%d vs %d)", len(a), len(b))
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0, nil
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
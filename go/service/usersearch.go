@@ -4,13 +4,22 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/keybase/client/go/contacts"
+	"github.com/keybase/client/go/contacts/vectorstore"
 	"github.com/keybase/client/go/externals"
 	"github.com/keybase/client/go/libkb"
 	keybase1 "github.com/keybase/client/go/protocol/keybase1"
@@ -20,6 +29,23 @@ import (
 	"golang.org/x/text/unicode/norm"
 )
 
+// contactEmbeddingTag tags every contact embedding stored in a
+// vectorstore.Store, so FindSimilar can be scoped to contacts even if the
+// store is later shared with other embedding kinds.
+const contactEmbeddingTag = "contact"
+
+// semanticRawScoreWeight is how much a semantic (vector) hit counts for in
+// the same 0..1 RawScore space the regex-based scorer produces, so the two
+// can be merged and sorted together.
+const semanticRawScoreWeight = 0.6
+
+// minSemanticSimilarity is the normalized-similarity floor a vector hit
+// must clear before it can stand in for a regex/fuzzy match. Real
+// embedding models produce generally-positive cosine similarity between
+// unrelated strings, so without a floor every synced contact would come
+// back as a "match" for every query.
+const minSemanticSimilarity = 0.5
+
 type UserSearchProvider interface {
 	MakeSearchRequest(libkb.MetaContext, keybase1.UserSearchArg) ([]keybase1.APIUserSearchResult, error)
 }
@@ -31,6 +57,36 @@ type UserSearchHandler struct {
 	contactsProvider *contacts.CachedContactsProvider
 	// Tests can overwrite searchProvider with mock types.
 	searchProvider UserSearchProvider
+
+	// vectorStore and embedder back semantic contact search when set; both
+	// are nil (and semantic search a no-op) until SetVectorStore is called.
+	vectorStore vectorstore.Store
+	embedder    vectorstore.EmbeddingProvider
+
+	// pagedResultsMu guards pagedResultsCache, the full scored-and-sorted
+	// result set behind the page currently being scrolled through, so a
+	// page past the first one doesn't have to redo the whole pipeline
+	// (including a remote API round-trip) just to slice a different range.
+	pagedResultsMu    sync.Mutex
+	pagedResultsCache map[string]pagedResultsCacheEntry
+}
+
+// pagedResultsCacheEntry is one cached UserSearchPaginated result set,
+// keyed by userSearchQueryHash. It's only reused while epoch still matches
+// contactSortEpoch; a resync invalidates every entry at once by bumping
+// the epoch rather than by walking the cache.
+type pagedResultsCacheEntry struct {
+	epoch   int64
+	results []keybase1.UserSearchResult
+}
+
+// SetVectorStore wires a vectorstore.Store and EmbeddingProvider into the
+// handler, enabling arg.Semantic in UserSearch/contactSearch. Production
+// calls this once at startup with a LocalStore and a real embedding
+// model; tests can inject a vectorstore.HashingEmbedder for determinism.
+func (h *UserSearchHandler) SetVectorStore(store vectorstore.Store, embedder vectorstore.EmbeddingProvider) {
+	h.vectorStore = store
+	h.embedder = embedder
 }
 
 func NewUserSearchHandler(xp rpc.Transporter, g *libkb.GlobalContext, provider *contacts.CachedContactsProvider) *UserSearchHandler {
@@ -100,9 +156,10 @@ func queryToRegexp(q string) (*regexp.Regexp, error) {
 type compiledQuery struct {
 	query string
 	rxx   *regexp.Regexp
+	fuzzy bool
 }
 
-func compileQuery(query string) (res compiledQuery, err error) {
+func compileQuery(query string, fuzzy bool) (res compiledQuery, err error) {
 	query = normalizeText(query)
 	rxx, err := queryToRegexp(query)
 	if err != nil {
@@ -111,6 +168,7 @@ func compileQuery(query string) (res compiledQuery, err error) {
 	res = compiledQuery{
 		query: query,
 		rxx:   rxx,
+		fuzzy: fuzzy,
 	}
 	return res, nil
 }
@@ -121,18 +179,139 @@ func (q *compiledQuery) scoreString(str string) (bool, float64) {
 		return true, 1
 	}
 
-	index := q.rxx.FindStringIndex(norm)
-	if index == nil {
+	if index := q.rxx.FindStringIndex(norm); index != nil {
+		leadingScore := 1.0 / float64(1+index[0])
+		lengthScore := 1.0 / float64(1+len(norm))
+		imperfection := 0.5
+		return true, leadingScore * lengthScore * imperfection
+	}
+
+	if !q.fuzzy {
+		return false, 0
+	}
+	return q.fuzzyScoreString(norm)
+}
+
+// fuzzyEditBudget is how many edits we're willing to tolerate for a query
+// of length n before giving up on a fuzzy match - longer queries can
+// absorb more typos before the match stops meaning anything.
+func fuzzyEditBudget(n int) int {
+	switch {
+	case n <= 4:
+		return 1
+	case n <= 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// fuzzyScoreString matches q against str's whitespace/punctuation split
+// tokens within a small edit-distance budget. It's weighted at 0.4 times
+// the length score, so an exact match (score 1) always outranks it and a
+// regex match at the very start of an equally long string (0.5 times the
+// length score) does too; a regex match that starts further into a string
+// can score below a fuzzy one.
+func (q *compiledQuery) fuzzyScoreString(norm string) (bool, float64) {
+	k := fuzzyEditBudget(len(q.query))
+
+	bestEdits := k + 1
+	for _, token := range splitRxx.Split(norm, -1) {
+		if token == "" {
+			continue
+		}
+		if edits := boundedEditDistance(q.query, token, k); edits < bestEdits {
+			bestEdits = edits
+		}
+	}
+	if bestEdits > k {
 		return false, 0
 	}
 
-	leadingScore := 1.0 / float64(1+index[0])
 	lengthScore := 1.0 / float64(1+len(norm))
-	imperfection := 0.5
-	score := leadingScore * lengthScore * imperfection
+	score := float64(k-bestEdits+1) / float64(k+1) * lengthScore * 0.4
 	return true, score
 }
 
+// boundedEditDistance computes the Damerau-Levenshtein distance between a
+// and b, restricted to the diagonal band [-k, k] of the DP table and
+// capped at k+1 once a row's minimum exceeds k. A return value > k means
+// no alignment within budget k exists; the exact value above k is not
+// meaningful.
+func boundedEditDistance(a, b string, k int) int {
+	ar := []rune(a)
+	br := []rune(b)
+	if len(ar) < len(br) {
+		ar, br = br, ar
+	}
+	if len(ar)-len(br) > k {
+		return k + 1
+	}
+
+	const inf = 1 << 30
+	prev2 := make([]int, len(br)+1)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		lo := i - k
+		if lo < 0 {
+			lo = 0
+		}
+		hi := i + k
+		if hi > len(br) {
+			hi = len(br)
+		}
+		for j := range cur {
+			cur[j] = inf
+		}
+		rowMin := inf
+		if lo == 0 {
+			cur[0] = i
+			rowMin = i
+		}
+
+		for j := lo; j <= hi; j++ {
+			if j == 0 {
+				// Already seeded above as the deletion border.
+				continue
+			}
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			best := prev[j-1] + cost // substitute (or match)
+			if v := cur[j-1] + 1; v < best {
+				best = v // insert
+			}
+			if v := prev[j] + 1; v < best {
+				best = v // delete
+			}
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				if v := prev2[j-2] + cost; v < best {
+					best = v // transpose
+				}
+			}
+			cur[j] = best
+			if best < rowMin {
+				rowMin = best
+			}
+		}
+		if rowMin > k {
+			return k + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+
+	if prev[len(br)] > k {
+		return k + 1
+	}
+	return prev[len(br)]
+}
+
 var fieldsAndScores = []struct {
 	multiplier float64
 	plumb      bool // plumb the matched value to displayLabel
@@ -169,17 +348,26 @@ func matchAndScoreContact(query compiledQuery, contact keybase1.ProcessedContact
 	return found, currentScore * multiplier, plumbMatchedVal
 }
 
-func contactSearch(mctx libkb.MetaContext, arg keybase1.UserSearchArg) (res []keybase1.UserSearchResult, err error) {
+func contactSearch(mctx libkb.MetaContext, arg keybase1.UserSearchArg, store vectorstore.Store, embedder vectorstore.EmbeddingProvider) (res []keybase1.UserSearchResult, err error) {
 	contactsRes, err := mctx.G().SyncedContactList.RetrieveContacts(mctx)
 	if err != nil {
 		return res, err
 	}
 
-	query, err := compileQuery(arg.Query)
+	query, err := compileQuery(arg.Query, arg.Fuzzy)
 	if err != nil {
 		return res, nil
 	}
 
+	var semanticScores map[int]float64
+	if arg.Semantic && store != nil && embedder != nil {
+		if semanticScores, err = semanticContactScores(arg.Query, contactsRes, store, embedder); err != nil {
+			mctx.Warning("Semantic contact search failed, falling back to regex/fuzzy only: %s", err)
+			semanticScores = nil
+			err = nil
+		}
+	}
+
 	// Deduplicate on name and label - never return multiple identical rows
 	// even if separate components yielded them.
 	type displayNameAndLabel struct {
@@ -200,6 +388,12 @@ func contactSearch(mctx libkb.MetaContext, arg keybase1.UserSearchArg) (res []ke
 
 	for _, contactIter := range contactsRes {
 		found, score, matchedVal := matchAndScoreContact(query, contactIter)
+		if semScore, ok := semanticScores[contactIter.ContactIndex]; ok {
+			if semContribution := semScore * semanticRawScoreWeight; semContribution > score {
+				found = true
+				score = semContribution
+			}
+		}
 		if found {
 			// Copy contact because we are storing pointer to contact.
 			contact := contactIter
@@ -288,6 +482,64 @@ func contactSearch(mctx libkb.MetaContext, arg keybase1.UserSearchArg) (res []ke
 	return res, nil
 }
 
+// semanticContactScores embeds arg.Query and looks up its nearest
+// neighbours in store, returning each matched contact's cosine similarity,
+// normalized into 0..1 as (similarity+1)/2, keyed by ContactIndex so
+// contactSearch can merge it into the regex-based RawScore space.
+func semanticContactScores(query string, contacts []keybase1.ProcessedContact, store vectorstore.Store, embedder vectorstore.EmbeddingProvider) (map[int]float64, error) {
+	queryVec, err := embedder.Embed(query)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := store.FindSimilar(queryVec, len(contacts), contactEmbeddingTag)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[int]float64, len(matches))
+	for _, m := range matches {
+		// Cosine similarity ranges over [-1, 1]; normalize into the 0..1
+		// RawScore space before applying the floor below.
+		normalized := (m.Similarity + 1) / 2
+		if normalized < minSemanticSimilarity {
+			continue
+		}
+		idx, err := strconv.Atoi(m.Key)
+		if err != nil {
+			continue
+		}
+		scores[idx] = normalized
+	}
+	return scores, nil
+}
+
+// SyncContactEmbeddings (re)computes and stores embeddings for each
+// contact's name, username, and full name, keyed by ContactIndex. Callers
+// should run this after a contact list sync or local edit so semantic
+// search (arg.Semantic) stays current.
+func SyncContactEmbeddings(contacts []keybase1.ProcessedContact, store vectorstore.Store, embedder vectorstore.EmbeddingProvider) error {
+	for _, contact := range contacts {
+		fields := make([]string, 0, 3)
+		for _, v := range []string{contact.ContactName, contact.Username, contact.FullName} {
+			if v != "" {
+				fields = append(fields, v)
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		vec, err := embedder.Embed(strings.Join(fields, " "))
+		if err != nil {
+			return err
+		}
+		key := strconv.Itoa(contact.ContactIndex)
+		if err := store.Set(key, vec, []string{contactEmbeddingTag}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func imptofuQueryToAssertion(typ keybase1.ImpTofuSearchType, val string) (string, error) {
 	switch typ {
 	case keybase1.ImpTofuSearchType_PHONE:
@@ -490,6 +742,28 @@ func (h *UserSearchHandler) UserSearch(ctx context.Context, arg keybase1.UserSea
 		panic("Invalid service name trap")
 	}
 
+	res, err = h.userSearchUnpaged(mctx, arg)
+	if err != nil {
+		return res, err
+	}
+
+	// Trim the whole result to MaxResult.
+	maxRes := arg.MaxResults
+	if maxRes > 0 && len(res) > maxRes {
+		res = res[:maxRes]
+	}
+
+	return res, nil
+}
+
+// userSearchUnpaged runs the full contacts + remote-API + imptofu pipeline
+// and returns it fully scored and sorted, without trimming the merged
+// result to MaxResults. arg.MaxResults still caps the contact hits (in
+// contactSearch) and the number of rows asked of the remote API.
+// UserSearch trims the result itself; UserSearchPaginated slices out a
+// page instead, since a hard MaxResults trim and proper pagination can't
+// coexist without one silently invalidating the other.
+func (h *UserSearchHandler) userSearchUnpaged(mctx libkb.MetaContext, arg keybase1.UserSearchArg) (res []keybase1.UserSearchResult, err error) {
 	apiRes, err := h.makeSearchRequest(mctx, arg)
 	if err != nil {
 		return res, err
@@ -500,7 +774,7 @@ func (h *UserSearchHandler) UserSearch(ctx context.Context, arg keybase1.UserSea
 	}
 
 	if arg.IncludeContacts {
-		contactsRes, err := contactSearch(mctx, arg)
+		contactsRes, err := contactSearch(mctx, arg, h.vectorStore, h.embedder)
 		if err != nil {
 			mctx.Warning("Failed to do contacts search: %s", err)
 		} else {
@@ -570,14 +844,274 @@ func (h *UserSearchHandler) UserSearch(ctx context.Context, arg keybase1.UserSea
 		}
 	}
 
-	// Trim the whole result to MaxResult.
-	maxRes := arg.MaxResults
-	if maxRes > 0 && len(res) > maxRes {
-		res = res[:maxRes]
+	return res, nil
+}
+
+// UserSearchPage is the pagination-aware counterpart to a plain
+// UserSearch response: besides the current page of Results, it reports
+// TotalCount and an opaque NextPageToken so a client can keep scrolling
+// past MaxResults without re-scoring the whole set on every page.
+type UserSearchPage struct {
+	Results       []keybase1.UserSearchResult
+	NextPageToken string
+	TotalCount    int
+}
+
+// contactSortEpoch is bumped every time the local contact list is
+// resynced, so a NextPageToken minted before a resync is detected as
+// stale instead of being paged against a result set whose scores (and
+// therefore order) just shifted underneath it.
+var contactSortEpoch int64
+
+// BumpContactSortEpoch should be called whenever the local contact list
+// changes in a way that could reorder search results (e.g. a background
+// sync). It invalidates any NextPageToken already handed out.
+func BumpContactSortEpoch() {
+	atomic.AddInt64(&contactSortEpoch, 1)
+}
+
+// pageTokenPayload is the (opaque to callers) contents of a
+// UserSearchPage.NextPageToken.
+type pageTokenPayload struct {
+	QueryHash string `json:"q"`
+	Offset    int    `json:"o"`
+	SortEpoch int64  `json:"e"`
+}
+
+func userSearchQueryHash(arg keybase1.UserSearchArg) string {
+	sum := sha256.Sum256([]byte(arg.Service + "\x00" + arg.Query))
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodePageToken(p pageTokenPayload) (string, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+func decodePageToken(token string) (p pageTokenPayload, err error) {
+	b, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return p, err
+	}
+	err = json.Unmarshal(b, &p)
+	return p, err
+}
+
+// cachedFullResults returns the full scored-and-sorted result set for arg,
+// reusing the last one computed for queryHash if it's still current for
+// epoch, so that scrolling through a multi-page search only runs the
+// contacts + remote-API + imptofu pipeline once instead of once per page.
+func (h *UserSearchHandler) cachedFullResults(mctx libkb.MetaContext, arg keybase1.UserSearchArg, queryHash string, epoch int64) ([]keybase1.UserSearchResult, error) {
+	h.pagedResultsMu.Lock()
+	if entry, ok := h.pagedResultsCache[queryHash]; ok && entry.epoch == epoch {
+		h.pagedResultsMu.Unlock()
+		return entry.results, nil
+	}
+	h.pagedResultsMu.Unlock()
+
+	// Compute the full scored set ourselves; MaxResults=0 disables the
+	// unpaged pipeline's own trimming.
+	unpagedArg := arg
+	unpagedArg.MaxResults = 0
+
+	full, err := h.userSearchUnpaged(mctx, unpagedArg)
+	if err != nil {
+		return nil, err
+	}
+
+	h.pagedResultsMu.Lock()
+	if h.pagedResultsCache == nil {
+		h.pagedResultsCache = make(map[string]pagedResultsCacheEntry)
+	}
+	h.pagedResultsCache[queryHash] = pagedResultsCacheEntry{epoch: epoch, results: full}
+	h.pagedResultsMu.Unlock()
+
+	return full, nil
+}
+
+// UserSearchPaginated is the pagination-aware counterpart to UserSearch.
+// Rather than applying MaxResults as a hard cap on the fully-scored
+// result set, it slices out [Offset, Offset+PageSize) - resuming from
+// arg.PageToken if one was given - and reports TotalCount across all
+// three sources plus a NextPageToken for the following page.
+func (h *UserSearchHandler) UserSearchPaginated(ctx context.Context, arg keybase1.UserSearchArg) (res UserSearchPage, err error) {
+	mctx := libkb.NewMetaContext(ctx, h.G()).WithLogTag("USEARCH")
+	defer mctx.TraceTimed(fmt.Sprintf("UserSearch#UserSearchPaginated(s=%q, q=%q)", arg.Service, arg.Query),
+		func() error { return err })()
+
+	if arg.Offset < 0 {
+		return res, fmt.Errorf("negative offset %d", arg.Offset)
+	}
+
+	offset := arg.Offset
+	epoch := atomic.LoadInt64(&contactSortEpoch)
+	queryHash := userSearchQueryHash(arg)
+	if arg.PageToken != "" {
+		token, tErr := decodePageToken(arg.PageToken)
+		if tErr != nil {
+			return res, fmt.Errorf("invalid page token: %w", tErr)
+		}
+		if token.QueryHash != queryHash || token.SortEpoch != epoch {
+			return res, errors.New("page token is stale; restart paging from offset 0")
+		}
+		if token.Offset < 0 {
+			return res, errors.New("invalid page token: negative offset")
+		}
+		offset = token.Offset
+	}
+
+	full, err := h.cachedFullResults(mctx, arg, queryHash, epoch)
+	if err != nil {
+		return res, err
+	}
+	res.TotalCount = len(full)
+
+	if offset >= len(full) {
+		return res, nil
+	}
+
+	end := len(full)
+	if arg.PageSize > 0 && offset+arg.PageSize < end {
+		end = offset + arg.PageSize
+	}
+	res.Results = full[offset:end]
+
+	if end < len(full) {
+		if res.NextPageToken, err = encodePageToken(pageTokenPayload{
+			QueryHash: queryHash,
+			Offset:    end,
+			SortEpoch: epoch,
+		}); err != nil {
+			return res, err
+		}
 	}
 
 	return res, nil
+}
+
+// UserSearchPhase identifies which stage of a UserSearchStream response a
+// UserSearchDelta came from.
+type UserSearchPhase int
+
+const (
+	UserSearchPhaseContacts UserSearchPhase = iota
+	UserSearchPhaseImpTofu
+	UserSearchPhaseRemote
+)
+
+// UserSearchDelta is one frame of a UserSearchStream response. SeqID
+// echoes arg.SeqID so a caller that fired off a newer query while an older
+// one is still streaming can drop frames from the superseded one instead
+// of racing to render stale results.
+type UserSearchDelta struct {
+	SeqID   int
+	Phase   UserSearchPhase
+	Results []keybase1.UserSearchResult
+	Done    bool
+}
+
+// userSearchStreamUI is the push side of UserSearchStream: the GUI
+// implements it to receive phases as they complete instead of waiting for
+// the whole RPC to return.
+type userSearchStreamUI interface {
+	UserSearchUpdate(context.Context, UserSearchDelta) error
+}
+
+func (h *UserSearchHandler) getUserSearchStreamUI(sessionID int) userSearchStreamUI {
+	return &userSearchStreamUIClient{
+		cli:       rpc.NewClient(h.rpcClient(), libkb.NewContextifiedErrorUnwrapper(h.G()), nil),
+		sessionID: sessionID,
+	}
+}
+
+type userSearchStreamUIClient struct {
+	cli       *rpc.Client
+	sessionID int
+}
+
+func (u *userSearchStreamUIClient) UserSearchUpdate(ctx context.Context, delta UserSearchDelta) error {
+	return u.cli.Call(ctx, "keybase.1.userSearchUi.userSearchUpdate", []interface{}{u.sessionID, delta}, nil, 0)
+}
+
+// UserSearchStream runs the same three data sources as UserSearch, but
+// pushes each phase to the caller's UI as soon as it's ready: local
+// contacts first, then imptofu resolution, then the (slower) remote API
+// search. A canceled ctx aborts whichever phase is outstanding, including
+// the remote API call; arg.PhaseDeadlineMs additionally bounds how long
+// the remote phase alone is allowed to take.
+func (h *UserSearchHandler) UserSearchStream(ctx context.Context, sessionID int, arg keybase1.UserSearchArg) (err error) {
+	mctx := libkb.NewMetaContext(ctx, h.G()).WithLogTag("USEARCHSTREAM")
+	defer mctx.TraceTimed(fmt.Sprintf("UserSearch#UserSearchStream(s=%q, q=%q)", arg.Service, arg.Query),
+		func() error { return err })()
+
+	ui := h.getUserSearchStreamUI(sessionID)
+
+	if arg.Query == "" {
+		return ui.UserSearchUpdate(ctx, UserSearchDelta{SeqID: arg.SeqID, Done: true})
+	}
+
+	if arg.IncludeContacts {
+		contactsRes, cErr := contactSearch(mctx, arg, h.vectorStore, h.embedder)
+		if cErr != nil {
+			mctx.Warning("UserSearchStream: contact phase failed: %s", cErr)
+		} else if uErr := ui.UserSearchUpdate(ctx, UserSearchDelta{
+			SeqID:   arg.SeqID,
+			Phase:   UserSearchPhaseContacts,
+			Results: contactsRes,
+		}); uErr != nil {
+			return uErr
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if arg.ImpTofuQuery != nil {
+		imptofuRes, iErr := imptofuSearch(mctx, h.contactsProvider, *arg.ImpTofuQuery)
+		if iErr != nil {
+			mctx.Warning("UserSearchStream: imptofu phase failed: %s", iErr)
+		} else if imptofuRes != nil {
+			if uErr := ui.UserSearchUpdate(ctx, UserSearchDelta{
+				SeqID:   arg.SeqID,
+				Phase:   UserSearchPhaseImpTofu,
+				Results: []keybase1.UserSearchResult{*imptofuRes},
+			}); uErr != nil {
+				return uErr
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	remoteCtx := ctx
+	if arg.PhaseDeadlineMs > 0 {
+		var cancel context.CancelFunc
+		remoteCtx, cancel = context.WithTimeout(ctx, time.Duration(arg.PhaseDeadlineMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	apiRes, err := h.makeSearchRequest(libkb.NewMetaContext(remoteCtx, h.G()), arg)
+	if err != nil {
+		return err
+	}
 
+	remoteRes := make([]keybase1.UserSearchResult, len(apiRes))
+	for i, v := range apiRes {
+		remoteRes[i] = makeUserSearchResult(v, arg.Service)
+	}
+
+	return ui.UserSearchUpdate(ctx, UserSearchDelta{
+		SeqID:   arg.SeqID,
+		Phase:   UserSearchPhaseRemote,
+		Results: remoteRes,
+		Done:    true,
+	})
 }
 
 func (h *UserSearchHandler) GetNonUserDetails(ctx context.Context, arg keybase1.GetNonUserDetailsArg) (res keybase1.NonUserDetails, err error) {
@@ -650,3 +1184,98 @@ func (h *UserSearchHandler) GetNonUserDetails(ctx context.Context, arg keybase1.
 
 	return res, nil
 }
+
+// imptofuQueryFor builds the keybase1.ImpTofuQuery union for service
+// ("phone" or "email") and value, as parsed out of an assertion URL.
+func imptofuQueryFor(service, value string) (keybase1.ImpTofuQuery, error) {
+	switch service {
+	case "phone":
+		return keybase1.NewImpTofuQueryWithPhone(keybase1.RawPhoneNumber(value)), nil
+	case "email":
+		return keybase1.NewImpTofuQueryWithEmail(keybase1.EmailAddress(value)), nil
+	default:
+		return keybase1.ImpTofuQuery{}, fmt.Errorf("unsupported imptofu service %q", service)
+	}
+}
+
+// userSearchByAssertionKeybase resolves a bare Keybase username assertion
+// to a UID and builds the single authoritative result for it.
+func (h *UserSearchHandler) userSearchByAssertionKeybase(mctx libkb.MetaContext, username string) (res keybase1.UserSearchResult, err error) {
+	resolved, err := libkb.ResolveUser(mctx, keybase1.UserAssertion(username))
+	if err != nil {
+		return res, err
+	}
+
+	name := resolved.GetName()
+	res = keybase1.UserSearchResult{
+		Id:              name,
+		Assertion:       name,
+		Username:        name,
+		KeybaseUsername: name,
+		Uid:             resolved.GetUID(),
+		ServiceName:     "keybase",
+		PrettyName:      name,
+		BubbleText:      fmt.Sprintf("%s on Keybase", name),
+		Source:          keybase1.NewUserSearchSourceDefault(keybase1.UserSearchSourceType_KEYBASE),
+	}
+	return res, nil
+}
+
+// userSearchByAssertionSocial issues a targeted, single-result search
+// against the remote API for a social assertion like "alice@twitter".
+func (h *UserSearchHandler) userSearchByAssertionSocial(mctx libkb.MetaContext, username, service string) (res keybase1.UserSearchResult, err error) {
+	apiRes, err := h.makeSearchRequest(mctx, keybase1.UserSearchArg{
+		Query:      username,
+		Service:    service,
+		MaxResults: 1,
+	})
+	if err != nil {
+		return res, err
+	}
+	for _, v := range apiRes {
+		if v.Service != nil && strings.EqualFold(v.Service.Username, username) && string(v.Service.ServiceName) == service {
+			return makeUserSearchResult(v, service), nil
+		}
+	}
+	return res, fmt.Errorf("no exact match for %s@%s", username, service)
+}
+
+// UserSearchByAssertion parses assertion - mirroring what
+// GetNonUserDetails already does - and resolves it directly, bypassing
+// contactSearch/compileQuery entirely.
+func (h *UserSearchHandler) UserSearchByAssertion(ctx context.Context, assertion string) (res keybase1.UserSearchResult, err error) {
+	mctx := libkb.NewMetaContext(ctx, h.G()).WithLogTag("USEARCH")
+	defer mctx.TraceTimed(fmt.Sprintf("UserSearch#UserSearchByAssertion(%q)", assertion),
+		func() error { return err })()
+
+	actx := mctx.G().MakeAssertionContext(mctx)
+	url, err := libkb.ParseAssertionURL(actx, assertion, true /* strict */)
+	if err != nil {
+		return res, err
+	}
+
+	username := url.GetValue()
+	service := url.GetKey()
+
+	switch {
+	case url.IsKeybase():
+		return h.userSearchByAssertionKeybase(mctx, username)
+	case url.IsSocial():
+		return h.userSearchByAssertionSocial(mctx, username, service)
+	case service == "phone" || service == "email":
+		query, qErr := imptofuQueryFor(service, username)
+		if qErr != nil {
+			return res, qErr
+		}
+		imptofuRes, iErr := imptofuSearch(mctx, h.contactsProvider, query)
+		if iErr != nil {
+			return res, iErr
+		}
+		if imptofuRes == nil {
+			return res, fmt.Errorf("no result for assertion %q", assertion)
+		}
+		return *imptofuRes, nil
+	default:
+		return res, fmt.Errorf("unsupported assertion service %q", service)
+	}
+}
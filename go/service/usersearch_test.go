@@ -0,0 +1,44 @@
+package service
+
+import "testing"
+
+func TestBoundedEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		k    int
+		want int
+	}{
+		{"a", "aa", 1, 1},
+		{"", "", 1, 0},
+		{"", "a", 1, 1},
+		{"", "abc", 1, 2}, // true distance 3 exceeds budget 1, capped at k+1
+		{"kitten", "sitting", 3, 3},
+		{"kitten", "sitting", 1, 2}, // true distance 3 exceeds budget 1, capped at k+1
+		{"same", "same", 0, 0},
+	}
+	for _, c := range cases {
+		got := boundedEditDistance(c.a, c.b, c.k)
+		if got != c.want {
+			t.Errorf("boundedEditDistance(%q, %q, %d) = %d, want %d", c.a, c.b, c.k, got, c.want)
+		}
+	}
+}
+
+func TestFuzzyScoreString(t *testing.T) {
+	q, err := compileQuery("jonh", true)
+	if err != nil {
+		t.Fatalf("compileQuery: %s", err)
+	}
+
+	found, score := q.scoreString("John Smith")
+	if !found {
+		t.Fatal("expected a fuzzy match for a one-transposition typo")
+	}
+	if score <= 0 {
+		t.Fatalf("expected a positive score, got %f", score)
+	}
+
+	if found, _ := q.scoreString("Completely Unrelated"); found {
+		t.Fatal("did not expect a match for an unrelated string")
+	}
+}
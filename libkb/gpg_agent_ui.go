@@ -0,0 +1,64 @@
+package libkb
+
+import (
+	keybase1 "github.com/keybase/client/go/protocol/keybase1"
+
+	"github.com/jcmdev0/gpgagent"
+)
+
+// GpgAgentSecretUI satisfies passphrase prompts through a running
+// gpg-agent, keyed on the locked key's fingerprint, before falling back to
+// Fallback. It's used by Keyrings.GetSecretKey when SecretKeyArg.UseAgent
+// is set, so unlocking a PGP key can reuse whatever gpg-agent already has
+// cached instead of re-prompting the user.
+type GpgAgentSecretUI struct {
+	Fallback    SecretUI
+	Fingerprint PgpFingerprint
+}
+
+func (g GpgAgentSecretUI) GetSecret(pinentry keybase1.SecretEntryArg, terminal *keybase1.SecretEntryArg) (res *keybase1.SecretEntryRes, err error) {
+	conn, err := gpgagent.NewConn()
+	if err != nil {
+		G.Log.Debug("| gpg-agent unreachable (%s), falling back to normal prompt", err)
+		return g.Fallback.GetSecret(pinentry, terminal)
+	}
+	defer conn.Close()
+
+	req := gpgagent.PassphraseRequest{
+		CacheKey: g.Fingerprint.String(),
+		Prompt:   pinentry.Prompt,
+		Desc:     pinentry.Desc,
+		Error:    pinentry.Error,
+	}
+
+	passphrase, err := conn.GetPassphrase(&req)
+	if err != nil {
+		G.Log.Debug("| gpg-agent GET_PASSPHRASE failed (%s), falling back to normal prompt", err)
+		return g.Fallback.GetSecret(pinentry, terminal)
+	}
+
+	return &keybase1.SecretEntryRes{Text: passphrase}, nil
+}
+
+// clearGpgAgentPassphrase tells gpg-agent to forget whatever it has cached
+// for fp, so a bad passphrase doesn't keep getting replayed on retry.
+func clearGpgAgentPassphrase(fp PgpFingerprint) error {
+	conn, err := gpgagent.NewConn()
+	if err != nil {
+		// No agent running; nothing to clear.
+		return nil
+	}
+	defer conn.Close()
+	return conn.ClearPassphrase(fp.String())
+}
+
+// gpgAgentReachable reports whether a gpg-agent looks reachable, so callers
+// can decide whether it's worth wrapping a SecretUI at all.
+func gpgAgentReachable() bool {
+	conn, err := gpgagent.NewConn()
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -1,6 +1,7 @@
 package libkb
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"os"
@@ -8,6 +9,8 @@ import (
 	"sync"
 
 	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
 )
 
 type KeyringFile struct {
@@ -16,6 +19,12 @@ type KeyringFile struct {
 	isPublic         bool
 	indexId          map[string](*openpgp.Entity) // Map of 64-bit uppercase-hex KeyIds
 	indexFingerprint map[PgpFingerprint](*openpgp.Entity)
+	indexEmail       map[string](*openpgp.Entity) // Lowercased email -> entity
+	indexUserId      map[string](*openpgp.Entity) // Raw UserId.Name -> entity
+
+	// Armored controls whether WriteTo/Save emit ASCII-armored output.
+	// It does not affect Load, which sniffs the file itself.
+	Armored bool
 }
 
 type Keyrings struct {
@@ -28,7 +37,7 @@ type Keyrings struct {
 func (k Keyrings) MakeKeyrings(filenames []string, isPublic bool) []*KeyringFile {
 	v := make([]*KeyringFile, len(filenames), len(filenames))
 	for i, filename := range filenames {
-		v[i] = &KeyringFile{filename, openpgp.EntityList{}, isPublic, nil, nil}
+		v[i] = &KeyringFile{filename, openpgp.EntityList{}, isPublic, nil, nil, nil, nil, false}
 	}
 	return v
 }
@@ -101,6 +110,46 @@ func (k Keyrings) FindKey(fp PgpFingerprint, secret bool) *openpgp.Entity {
 
 //===================================================================
 
+// FindKeyByEmail looks up an entity by one of its user IDs' email
+// addresses, case-insensitively. Set secret to search the secret
+// keyrings instead of the public ones.
+func (k Keyrings) FindKeyByEmail(email string, secret bool) *openpgp.Entity {
+	var l []*KeyringFile
+	if secret {
+		l = k.Secret
+	} else {
+		l = k.Public
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	for _, file := range l {
+		if entity, found := file.indexEmail[email]; found {
+			return entity
+		}
+	}
+	return nil
+}
+
+// FindKeysByUserIdSubstring returns every entity with a user ID name
+// containing q, case-insensitively.
+func (k Keyrings) FindKeysByUserIdSubstring(q string, secret bool) []*openpgp.Entity {
+	var l []*KeyringFile
+	if secret {
+		l = k.Secret
+	} else {
+		l = k.Public
+	}
+	q = strings.ToLower(q)
+	var out []*openpgp.Entity
+	for _, file := range l {
+		for name, entity := range file.indexUserId {
+			if strings.Contains(strings.ToLower(name), q) {
+				out = append(out, entity)
+			}
+		}
+	}
+	return out
+}
+
 func (k *Keyrings) Load() (err error) {
 	G.Log.Debug("+ Loading keyrings")
 	if k.Public != nil {
@@ -163,6 +212,8 @@ func (k *KeyringFile) Index() error {
 	G.Log.Debug("+ Index on %s", k.filename)
 	k.indexId = make(map[string](*openpgp.Entity))
 	k.indexFingerprint = make(map[PgpFingerprint](*openpgp.Entity))
+	k.indexEmail = make(map[string](*openpgp.Entity))
+	k.indexUserId = make(map[string](*openpgp.Entity))
 	p := 0
 	s := 0
 	for _, entity := range k.Entities {
@@ -182,12 +233,75 @@ func (k *KeyringFile) Index() error {
 				s++
 			}
 		}
+		k.indexIdentities(entity)
 	}
 	G.Log.Debug("| Indexed %d primary and %d subkeys", p, s)
 	G.Log.Debug("- Index on %s -> %s", k.filename, "OK")
 	return nil
 }
 
+// indexIdentities walks entity's user IDs, indexing each by email and by
+// raw name so keys can be looked up the way users actually identify them.
+// When two entities claim the same email or name, the one with the newer
+// self-signature wins.
+func (k *KeyringFile) indexIdentities(entity *openpgp.Entity) {
+	for _, identity := range entity.Identities {
+		if identity.UserId == nil {
+			continue
+		}
+		if email := strings.ToLower(strings.TrimSpace(identity.UserId.Email)); email != "" {
+			matches := func(id *openpgp.Identity) bool {
+				return id.UserId != nil && strings.ToLower(strings.TrimSpace(id.UserId.Email)) == email
+			}
+			if !k.newerIdentityWins(k.indexEmail[email], candidateWithMatcher{identity, matches}) {
+				continue
+			}
+			k.indexEmail[email] = entity
+		}
+		if name := identity.UserId.Name; name != "" {
+			matches := func(id *openpgp.Identity) bool {
+				return id.UserId != nil && id.UserId.Name == name
+			}
+			if !k.newerIdentityWins(k.indexUserId[name], candidateWithMatcher{identity, matches}) {
+				continue
+			}
+			k.indexUserId[name] = entity
+		}
+	}
+}
+
+// candidateWithMatcher pairs a candidate identity with a predicate that
+// picks out the identity on some other entity that claims the same
+// email/name, so newerIdentityWins can compare the right two identities.
+type candidateWithMatcher struct {
+	identity *openpgp.Identity
+	matches  func(*openpgp.Identity) bool
+}
+
+// newerIdentityWins reports whether candidate should replace the entity
+// currently indexed under this email/name (if any). An entity can have
+// several identities, so it finds the one on current that actually
+// matches the email/name being indexed before comparing self-signature
+// creation times - comparing against an arbitrary identity would make the
+// "newest wins" rule depend on Go's randomized map iteration order.
+func (k *KeyringFile) newerIdentityWins(current *openpgp.Entity, candidate candidateWithMatcher) bool {
+	if current == nil {
+		return true
+	}
+	for _, currentIdentity := range current.Identities {
+		if !candidate.matches(currentIdentity) {
+			continue
+		}
+		if currentIdentity.SelfSignature == nil || candidate.identity.SelfSignature == nil {
+			return true
+		}
+		return candidate.identity.SelfSignature.CreationTime.After(currentIdentity.SelfSignature.CreationTime)
+	}
+	// current was indexed under this email/name, so it should always have
+	// a matching identity; if it somehow doesn't, prefer the candidate.
+	return true
+}
+
 func (k *KeyringFile) Load() error {
 	G.Log.Debug(fmt.Sprintf("+ Loading PGP Keyring %s", k.filename))
 	file, err := os.Open(k.filename)
@@ -199,7 +313,17 @@ func (k *KeyringFile) Load() error {
 		return err
 	}
 	if file != nil {
-		k.Entities, err = openpgp.ReadKeyRing(file)
+		br := bufio.NewReader(file)
+		var armored bool
+		if armored, err = isArmoredKeyring(br); err != nil {
+			G.Log.Error(fmt.Sprintf("Cannot sniff keyring %s: %s\n", k.filename, err.Error()))
+			return err
+		}
+		if armored {
+			k.Entities, err = openpgp.ReadArmoredKeyRing(br)
+		} else {
+			k.Entities, err = openpgp.ReadKeyRing(br)
+		}
 		if err != nil {
 			G.Log.Error(fmt.Sprintf("Cannot parse keyring %s: %s\n", err.Error()))
 			return err
@@ -209,9 +333,57 @@ func (k *KeyringFile) Load() error {
 	return nil
 }
 
+// armorHeaderPrefix is the start of the dash-armor header line that marks
+// an ASCII-armored OpenPGP file (RFC 4880 section 6.2).
+const armorHeaderPrefix = "-----BEGIN PGP"
+
+// isArmoredKeyring peeks at the front of br to tell an ASCII-armored
+// keyring apart from a binary one, without consuming any bytes.
+func isArmoredKeyring(br *bufio.Reader) (bool, error) {
+	head, err := br.Peek(len(armorHeaderPrefix))
+	if err != nil {
+		if err == io.EOF {
+			// Short or empty file; let the real parser report the error.
+			return false, nil
+		}
+		return false, err
+	}
+	return string(head) == armorHeaderPrefix, nil
+}
+
 func (k KeyringFile) WriteTo(w io.Writer) error {
+	if k.Armored {
+		blockType := armor.PublicKeyType
+		if !k.isPublic {
+			blockType = armor.PrivateKeyType
+		}
+		aw, err := armor.Encode(w, blockType, nil)
+		if err != nil {
+			return err
+		}
+		if err := k.serializeEntities(aw); err != nil {
+			return err
+		}
+		return aw.Close()
+	}
+
+	return k.serializeEntities(w)
+}
+
+// serializeEntities writes every entity in the keyring to w. Secret
+// keyrings serialize the private-key packets (Entity.Serialize only ever
+// writes the public portion, even for an entity with a loaded private
+// key), so a secret KeyringFile round-trips its private keys instead of
+// silently dropping them.
+func (k KeyringFile) serializeEntities(w io.Writer) error {
 	for _, e := range k.Entities {
-		if err := e.Serialize(w); err != nil {
+		var err error
+		if k.isPublic {
+			err = e.Serialize(w)
+		} else {
+			err = e.SerializePrivate(w, nil)
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -224,6 +396,106 @@ func (k KeyringFile) Save() error {
 	return SafeWriteToFile(k)
 }
 
+// Export serializes just the entity matching fp, optionally ASCII-armored,
+// for round-tripping a single key out of the keyring.
+func (k KeyringFile) Export(fp PgpFingerprint, armored bool, w io.Writer) error {
+	entity, found := k.indexFingerprint[fp]
+	if !found {
+		return fmt.Errorf("no key found for fingerprint %s in %s", fp, k.filename)
+	}
+	single := KeyringFile{
+		filename: k.filename,
+		Entities: openpgp.EntityList{entity},
+		isPublic: k.isPublic,
+		Armored:  armored,
+	}
+	return single.WriteTo(w)
+}
+
+// Import reads one or more keys from r (armored or binary) and merges them
+// into the in-memory keyring, re-indexing afterward. Callers still need to
+// call Save to persist the change to disk.
+func (k *KeyringFile) Import(r io.Reader) error {
+	br := bufio.NewReader(r)
+	armored, err := isArmoredKeyring(br)
+	if err != nil {
+		return err
+	}
+
+	var entities openpgp.EntityList
+	if armored {
+		entities, err = openpgp.ReadArmoredKeyRing(br)
+	} else {
+		entities, err = openpgp.ReadKeyRing(br)
+	}
+	if err != nil {
+		return err
+	}
+
+	k.Entities = append(k.Entities, entities...)
+	return k.Index()
+}
+
+// NewKeyPair generates a fresh OpenPGP identity for (name, comment, email),
+// optionally locking it with passphrase, and appends it to the current
+// user's public and secret keyring files. Existing entities in each file
+// are re-serialized first, followed by the new one, which is required for
+// the result to parse correctly.
+func (k *Keyrings) NewKeyPair(name, comment, email string, cfg *packet.Config, passphrase []byte) (entity *openpgp.Entity, err error) {
+	G.Log.Debug("+ NewKeyPair(%s)", email)
+	defer func() {
+		G.Log.Debug("- NewKeyPair(%s) -> %s", email, ErrToOk(err))
+	}()
+
+	if entity, err = openpgp.NewEntity(name, comment, email, cfg); err != nil {
+		return nil, err
+	}
+
+	if len(passphrase) > 0 {
+		if err = entity.PrivateKey.Encrypt(passphrase); err != nil {
+			return nil, err
+		}
+		for _, subkey := range entity.Subkeys {
+			if subkey.PrivateKey != nil {
+				if err = subkey.PrivateKey.Encrypt(passphrase); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	var me *User
+	if me, err = LoadMe(LoadUserArg{}); err != nil {
+		return nil, err
+	}
+
+	for _, pub := range k.Public {
+		pub.Entities = append(pub.Entities, entity)
+		if err = pub.Index(); err != nil {
+			return nil, err
+		}
+		if err = pub.Save(); err != nil {
+			return nil, err
+		}
+	}
+
+	var skb *SKBKeyringFile
+	if skb, err = k.LoadSKBKeyring(me.name); err != nil {
+		return nil, err
+	}
+	if skb != nil {
+		skb.Entities = append(skb.Entities, entity)
+		if err = skb.Index(); err != nil {
+			return nil, err
+		}
+		if err = skb.Save(); err != nil {
+			return nil, err
+		}
+	}
+
+	return entity, nil
+}
+
 // GetSecretKeyLocked gets a secret key for the current user by first
 // looking for keys synced from the server, and if that fails, tries
 // those in the local Keyring that are also active for the user.
@@ -326,6 +598,10 @@ type SecretKeyArg struct {
 	Reason string   // why it's needed (for an Unlock)
 	Ui     SecretUI // for Unlocking secrets
 	Me     *User    // Whose keys
+
+	// UseAgent has GetSecretKey try gpg-agent for the passphrase before
+	// falling back to Ui.
+	UseAgent bool
 }
 
 func (s SecretKeyArg) UseDeviceKey() bool    { return s.All || s.DeviceKey }
@@ -341,11 +617,120 @@ func (k Keyrings) GetSecretKey(ska SecretKeyArg) (key GenericKey, err error) {
 	var which string
 	if skb, which, err = k.GetSecretKeyLocked(ska); err == nil && skb != nil {
 		G.Log.Debug("| Prompt/Unlock key")
-		key, err = skb.PromptAndUnlock(ska.Reason, which, ska.Ui)
+		ui := ska.Ui
+		if ska.UseAgent {
+			ui = GpgAgentSecretUI{Fallback: ska.Ui, Fingerprint: skb.Fingerprint()}
+		}
+		key, err = skb.PromptAndUnlock(ska.Reason, which, ui)
+		if err != nil && ska.UseAgent {
+			if clearErr := clearGpgAgentPassphrase(skb.Fingerprint()); clearErr != nil {
+				G.Log.Debug("| Failed to clear cached gpg-agent passphrase: %s", clearErr)
+			}
+		}
 	}
 	return
 }
 
+//===================================================================
+//
+// Higher-level PGP operations that resolve recipients/signers against the
+// loaded keyrings so callers don't have to re-implement the openpgp
+// pipeline themselves.
+//
+
+// findSigningEntity resolves ska to an unlocked openpgp.Entity, suitable
+// for use as the signer argument to openpgp.Encrypt or openpgp.Sign.
+func (k Keyrings) findSigningEntity(ska SecretKeyArg) (*openpgp.Entity, error) {
+	key, err := k.GetSecretKey(ska)
+	if err != nil {
+		return nil, err
+	}
+	entity, ok := key.(*openpgp.Entity)
+	if !ok {
+		return nil, fmt.Errorf("secret key %s is not a PGP key", ska.Reason)
+	}
+	return entity, nil
+}
+
+// EncryptTo encrypts plain for the given recipients' public keys, writing
+// the ciphertext to out. If signer has Me or Ui set, the message is also
+// signed with the secret key it resolves to. Set armored to wrap the output in
+// ASCII armor.
+func (k Keyrings) EncryptTo(recipients []PgpFingerprint, signer SecretKeyArg, plain io.Reader, out io.Writer, armored bool) (err error) {
+	to := make([]*openpgp.Entity, 0, len(recipients))
+	for _, fp := range recipients {
+		entity := k.FindKey(fp, false)
+		if entity == nil {
+			return fmt.Errorf("no public key found for fingerprint %s", fp)
+		}
+		to = append(to, entity)
+	}
+
+	var signerEntity *openpgp.Entity
+	if signer.Me != nil || signer.Ui != nil {
+		if signerEntity, err = k.findSigningEntity(signer); err != nil {
+			return err
+		}
+	}
+
+	w := out
+	if armored {
+		aw, err := armor.Encode(out, "PGP MESSAGE", nil)
+		if err != nil {
+			return err
+		}
+		defer aw.Close()
+		w = aw
+	}
+
+	pw, err := openpgp.Encrypt(w, to, signerEntity, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer pw.Close()
+
+	_, err = io.Copy(pw, plain)
+	return err
+}
+
+// Decrypt reads an (optionally signed) OpenPGP message from in, decrypting
+// it with whichever of our secret keys match, and writes the plaintext to
+// out. It returns the signer's key, if the message was signed and the
+// signer is in our keyrings.
+func (k Keyrings) Decrypt(in io.Reader, out io.Writer) (signer *openpgp.Key, err error) {
+	md, err := openpgp.ReadMessage(in, k, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(out, md.UnverifiedBody); err != nil {
+		return nil, err
+	}
+	if md.SignatureError != nil {
+		return md.SignedBy, md.SignatureError
+	}
+	return md.SignedBy, nil
+}
+
+// Sign writes a detached signature of plain, created with the secret key
+// ska resolves to, to out. Set armored to wrap the signature in ASCII
+// armor.
+func (k Keyrings) Sign(ska SecretKeyArg, plain io.Reader, out io.Writer, armored bool) error {
+	signer, err := k.findSigningEntity(ska)
+	if err != nil {
+		return err
+	}
+	if armored {
+		return openpgp.ArmoredDetachSign(out, signer, plain, nil)
+	}
+	return openpgp.DetachSign(out, signer, plain, nil)
+}
+
+// Verify checks signature against signed using whichever of our known
+// public keys produced it, returning that key's entity on success.
+func (k Keyrings) Verify(signed, signature io.Reader) (*openpgp.Entity, error) {
+	return openpgp.CheckDetachedSignature(k, signed, signature)
+}
+
 type EmptyKeyRing struct{}
 
 func (k EmptyKeyRing) KeysById(id uint64) []openpgp.Key {
@@ -0,0 +1,73 @@
+package libkb
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// TestKeyringFileWriteToSecretArmoredRoundTrip checks that a secret,
+// armored KeyringFile writes out private-key packets (not just the public
+// portion) and that the result parses back as an armored secret keyring
+// with the private key intact.
+func TestKeyringFileWriteToSecretArmoredRoundTrip(t *testing.T) {
+	entity, err := openpgp.NewEntity("tester", "", "tester@keybase.io", nil)
+	if err != nil {
+		t.Fatalf("NewEntity: %s", err)
+	}
+
+	secret := KeyringFile{
+		Entities: openpgp.EntityList{entity},
+		isPublic: false,
+		Armored:  true,
+	}
+
+	var buf bytes.Buffer
+	if err := secret.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %s", err)
+	}
+
+	parsed, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadArmoredKeyRing: %s", err)
+	}
+	if len(parsed) != 1 {
+		t.Fatalf("expected 1 entity, got %d", len(parsed))
+	}
+	if parsed[0].PrivateKey == nil {
+		t.Fatal("round-tripped entity has no private key; WriteTo dropped it")
+	}
+}
+
+// TestKeyringFileWriteToPublicArmoredRoundTrip checks that a public
+// keyring still round-trips as before: no private key material, even
+// though the entity has one available.
+func TestKeyringFileWriteToPublicArmoredRoundTrip(t *testing.T) {
+	entity, err := openpgp.NewEntity("tester", "", "tester@keybase.io", nil)
+	if err != nil {
+		t.Fatalf("NewEntity: %s", err)
+	}
+
+	public := KeyringFile{
+		Entities: openpgp.EntityList{entity},
+		isPublic: true,
+		Armored:  true,
+	}
+
+	var buf bytes.Buffer
+	if err := public.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %s", err)
+	}
+
+	parsed, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadArmoredKeyRing: %s", err)
+	}
+	if len(parsed) != 1 {
+		t.Fatalf("expected 1 entity, got %d", len(parsed))
+	}
+	if parsed[0].PrivateKey != nil {
+		t.Fatal("public keyring round-tripped a private key")
+	}
+}
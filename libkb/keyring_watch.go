@@ -0,0 +1,125 @@
+package libkb
+
+import (
+	"context"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// KeyringWatchOpts configures Keyrings.Watch.
+type KeyringWatchOpts struct {
+	// OnReload, if set, is called after a watched keyring file is
+	// successfully reloaded, with the path that changed. Higher layers can
+	// use it to invalidate their own caches of keyring-derived state.
+	OnReload func(path string)
+}
+
+// Watch watches every keyring file currently loaded into k — the public
+// and secret GPG keyrings, plus any per-user SKB keyrings already cached
+// in skbMap — for external writes (e.g. a `gpg --import` run out of
+// process) and reloads the affected file in place. It blocks until ctx is
+// canceled or the underlying watcher fails to start.
+//
+// Reload swaps in a freshly-built *KeyringFile rather than mutating the
+// existing one's maps, so a concurrent FindKey/KeysById/KeysByIdUsage call
+// always sees either the old snapshot or the new one, never a partially
+// rebuilt index.
+func (k *Keyrings) Watch(ctx context.Context, opts KeyringWatchOpts) (err error) {
+	G.Log.Debug("+ Keyrings.Watch")
+	defer G.Log.Debug("- Keyrings.Watch -> %s", ErrToOk(err))
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	k.Lock()
+	for _, list := range [][]*KeyringFile{k.Public, k.Secret} {
+		for _, file := range list {
+			if watchErr := watcher.Add(file.filename); watchErr != nil {
+				G.Log.Debug("| Watch: cannot watch %s: %s", file.filename, watchErr)
+			}
+		}
+	}
+	for _, skb := range k.skbMap {
+		if watchErr := watcher.Add(skb.filename); watchErr != nil {
+			G.Log.Debug("| Watch: cannot watch %s: %s", skb.filename, watchErr)
+		}
+	}
+	k.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			if reloadErr := k.reloadPath(watcher, ev.Name); reloadErr != nil {
+				G.Log.Debug("| Watch: reload of %s failed: %s", ev.Name, reloadErr)
+				continue
+			}
+			if opts.OnReload != nil {
+				opts.OnReload(ev.Name)
+			}
+		case watchErr, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			G.Log.Debug("| Watch: fsnotify error: %s", watchErr)
+		}
+	}
+}
+
+// reloadPath re-reads and re-indexes whichever loaded keyring (public,
+// secret, or per-user SKB) lives at path, swapping in a fresh KeyringFile
+// under k's mutex.
+//
+// SafeWriteToFile (what every Save writes through) replaces path via a
+// temp-file-plus-rename, as external tools like `gpg --import` also do,
+// which detaches
+// the inotify watch from the path's inode. reloadPath re-adds path to
+// watcher after a successful reload so later writes keep firing.
+func (k *Keyrings) reloadPath(watcher *fsnotify.Watcher, path string) error {
+	k.Lock()
+	defer k.Unlock()
+
+	for _, list := range [][]*KeyringFile{k.Public, k.Secret} {
+		for i, file := range list {
+			if file.filename != path {
+				continue
+			}
+			fresh := &KeyringFile{filename: path, isPublic: file.isPublic, Armored: file.Armored}
+			if err := fresh.LoadAndIndex(); err != nil {
+				return err
+			}
+			list[i] = fresh
+			if watchErr := watcher.Add(path); watchErr != nil {
+				G.Log.Debug("| reloadPath: cannot re-watch %s: %s", path, watchErr)
+			}
+			return nil
+		}
+	}
+
+	for un, skb := range k.skbMap {
+		if skb.filename != path {
+			continue
+		}
+		fresh := NewSKBKeyringFile(path)
+		if err := fresh.LoadAndIndex(); err != nil {
+			return err
+		}
+		k.skbMap[un] = fresh
+		if watchErr := watcher.Add(path); watchErr != nil {
+			G.Log.Debug("| reloadPath: cannot re-watch %s: %s", path, watchErr)
+		}
+		return nil
+	}
+
+	return nil
+}